@@ -0,0 +1,82 @@
+// Copyright © 2017 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/nikogura/gomason/pkg/gomason"
+	"github.com/spf13/cobra"
+	"io/ioutil"
+	"log"
+	"os"
+)
+
+var buildParallelism int
+
+// buildCmd represents the build command
+var buildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Build your project's binaries.",
+	Long: `
+Build your project's binaries.
+
+Runs 'test', then builds the binaries listed in metadata.json.  Pass --parallel to build more than one target at a time.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		workDir, err := ioutil.TempDir("", "gomason")
+		if err != nil {
+			log.Fatalf("Failed to create temp dir: %s", err)
+		}
+
+		defer os.RemoveAll(workDir)
+
+		gopath, err := gomason.CreateGoPath(workDir)
+		if err != nil {
+			log.Fatalf("Failed to create ephemeral GOPATH: %s", err)
+		}
+
+		meta, err := gomason.ReadMetadata("metadata.json")
+		if err != nil {
+			log.Fatalf("Failed to read metadata.json: %s", err)
+		}
+
+		err = gomason.Checkout(gopath, meta, branch, verbose)
+		if err != nil {
+			log.Fatalf("failed to checkout package %s at branch %s: %s", meta.Package, branch, err)
+		}
+
+		err = gomason.GoTest(gopath, meta.Package, verbose)
+		if err != nil {
+			log.Fatalf("error running go test: %s", err)
+		}
+
+		if buildParallelism > 1 {
+			err = gomason.BuildParallel(gopath, meta, branch, buildParallelism, verbose)
+		} else {
+			err = gomason.Build(gopath, meta, branch, verbose)
+		}
+
+		if err != nil {
+			log.Fatalf("build failed: %s", err)
+		}
+
+		log.Printf("Build Succeeded!\n\n")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(buildCmd)
+
+	buildCmd.Flags().IntVar(&buildParallelism, "parallel", 1, "Number of build targets to build concurrently.")
+}
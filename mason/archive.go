@@ -0,0 +1,238 @@
+package mason
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Archive bundles each build target's binary, plus any BuildExtras files (LICENSE,
+// README, config templates), into a .zip (the default for windows targets) or .tar.gz,
+// named <pkg>_<version>_<os>_<arch>.<ext>, writes a SHA256SUMS manifest covering every
+// archive, and, if sign is true, produces a detached signature over that manifest so
+// downstream verifiers only need to check one signature to trust all of the artifacts.
+//
+// Like WholeShebang's own build step, Archive looks for target.BuildTargets binaries
+// named "<prefix>_<os>_<arch>" in outDir - where WholeShebang collects them once a build
+// finishes - rather than in the ephemeral gopath they were originally compiled in.
+func Archive(meta Metadata, gopath, outDir string, format string, sign bool, verbose bool) (err error) {
+	parts := strings.Split(meta.Package, "/")
+	binaryPrefix := parts[len(parts)-1]
+
+	checksums := make(map[string]string)
+
+	for _, target := range meta.BuildTargets {
+		archparts := strings.Split(target, "/")
+		osname := archparts[0]
+		archname := archparts[1]
+
+		binary := fmt.Sprintf("%s/%s_%s_%s", outDir, binaryPrefix, osname, archname)
+
+		if osname == "windows" {
+			binary = fmt.Sprintf("%s.exe", binary)
+		}
+
+		if _, err = os.Stat(binary); os.IsNotExist(err) {
+			err = errors.Wrapf(err, "build target %s was not found.  Did Build succeed?", binary)
+			return err
+		}
+
+		files := []string{binary}
+
+		for _, extra := range meta.BuildExtras {
+			extraPath := fmt.Sprintf("%s/%s", outDir, extra)
+
+			if _, err = os.Stat(extraPath); os.IsNotExist(err) {
+				err = errors.Wrapf(err, "build extra %s was not found", extraPath)
+				return err
+			}
+
+			files = append(files, extraPath)
+		}
+
+		ext := format
+		if ext == "" {
+			if osname == "windows" {
+				ext = "zip"
+			} else {
+				ext = "tar.gz"
+			}
+		}
+
+		archiveName := fmt.Sprintf("%s_%s_%s_%s.%s", binaryPrefix, meta.Version, osname, archname, ext)
+		archivePath := fmt.Sprintf("%s/%s", outDir, archiveName)
+
+		if verbose {
+			log.Printf("Archiving %s into %s", strings.Join(files, ", "), archivePath)
+		}
+
+		var sum string
+
+		if ext == "zip" {
+			sum, err = archiveZip(archivePath, files)
+		} else {
+			sum, err = archiveTarGz(archivePath, files)
+		}
+
+		if err != nil {
+			err = errors.Wrapf(err, "failed to archive %s", archivePath)
+			return err
+		}
+
+		checksums[archiveName] = sum
+	}
+
+	manifestPath := fmt.Sprintf("%s/SHA256SUMS", outDir)
+
+	if err = writeManifest(manifestPath, checksums); err != nil {
+		err = errors.Wrap(err, "failed to write SHA256SUMS manifest")
+		return err
+	}
+
+	if sign {
+		if err = SignBinary(meta, manifestPath, verbose); err != nil {
+			err = errors.Wrap(err, "failed to sign SHA256SUMS manifest")
+			return err
+		}
+	}
+
+	return err
+}
+
+// writeManifest writes a SHA256SUMS file in the conventional "<hex digest>  <name>"
+// format, sorted by archive name so the manifest - which gets signed and diffed across
+// runs - is byte-for-byte reproducible rather than ordered by Go's random map iteration.
+func writeManifest(path string, checksums map[string]string) (err error) {
+	names := make([]string, 0, len(checksums))
+
+	for name := range checksums {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var lines []string
+
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("%s  %s", checksums[name], name))
+	}
+
+	return ioutil.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
+
+// archiveTarGz streams files into a gzip-compressed tarball, computing the archive's
+// SHA256 digest in the same pass.
+func archiveTarGz(destPath string, files []string) (sum string, err error) {
+	f, err := os.Create(destPath)
+	if err != nil {
+		return sum, err
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	mw := io.MultiWriter(f, hasher)
+
+	gzw := gzip.NewWriter(mw)
+	tw := tar.NewWriter(gzw)
+
+	for _, file := range files {
+		if err = addFileToTar(tw, file); err != nil {
+			return sum, err
+		}
+	}
+
+	if err = tw.Close(); err != nil {
+		return sum, err
+	}
+
+	if err = gzw.Close(); err != nil {
+		return sum, err
+	}
+
+	sum = fmt.Sprintf("%x", hasher.Sum(nil))
+
+	return sum, err
+}
+
+func addFileToTar(tw *tar.Writer, file string) (err error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = filepath.Base(file)
+
+	if err = tw.WriteHeader(header); err != nil {
+		return err
+	}
+
+	in, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	_, err = io.Copy(tw, in)
+
+	return err
+}
+
+// archiveZip streams files into a zip archive, computing the archive's SHA256 digest in
+// the same pass.
+func archiveZip(destPath string, files []string) (sum string, err error) {
+	f, err := os.Create(destPath)
+	if err != nil {
+		return sum, err
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	mw := io.MultiWriter(f, hasher)
+
+	zw := zip.NewWriter(mw)
+
+	for _, file := range files {
+		if err = addFileToZip(zw, file); err != nil {
+			return sum, err
+		}
+	}
+
+	if err = zw.Close(); err != nil {
+		return sum, err
+	}
+
+	sum = fmt.Sprintf("%x", hasher.Sum(nil))
+
+	return sum, err
+}
+
+func addFileToZip(zw *zip.Writer, file string) (err error) {
+	in, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	w, err := zw.Create(filepath.Base(file))
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, in)
+
+	return err
+}
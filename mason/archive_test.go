@@ -0,0 +1,32 @@
+package mason
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteManifestIsSortedAndDeterministic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gomason-manifest")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	checksums := map[string]string{
+		"zebra_linux_amd64.tar.gz":   "deadbeef",
+		"alpha_windows_amd64.zip":    "cafef00d",
+		"middle_darwin_arm64.tar.gz": "f00dcafe",
+	}
+
+	manifestPath := dir + "/SHA256SUMS"
+
+	assert.Nil(t, writeManifest(manifestPath, checksums))
+
+	got, err := ioutil.ReadFile(manifestPath)
+	assert.Nil(t, err)
+
+	want := "cafef00d  alpha_windows_amd64.zip\nf00dcafe  middle_darwin_arm64.tar.gz\ndeadbeef  zebra_linux_amd64.tar.gz\n"
+
+	assert.Equal(t, want, string(got), "writeManifest should sort lines by archive name for reproducibility")
+}
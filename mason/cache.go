@@ -0,0 +1,104 @@
+package mason
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultPurgeTimeout is how old a cache subdirectory can get before CacheDir()'s
+// callers should consider it stale and remove it, absent an explicit override (e.g. the
+// mason/cmd --purge-timeout flag).
+const DefaultPurgeTimeout = 30 * 24 * time.Hour
+
+// CacheDir returns the root directory ephemeral GOPATHs are cached under when the
+// --cache flag is set: $XDG_CACHE_HOME/gomason, falling back to ~/.cache/gomason.
+func CacheDir() (dir string, err error) {
+	if xdg := os.Getenv("XDG_CACHE_HOME"); xdg != "" {
+		dir = fmt.Sprintf("%s/gomason", xdg)
+	} else {
+		home, homeErr := os.UserHomeDir()
+		if homeErr != nil {
+			err = errors.Wrap(homeErr, "failed to determine home directory")
+			return dir, err
+		}
+
+		dir = fmt.Sprintf("%s/.cache/gomason", home)
+	}
+
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		err = errors.Wrapf(err, "failed to create cache dir %s", dir)
+		return dir, err
+	}
+
+	return dir, err
+}
+
+// CacheKey returns the content-addressed subdirectory name for a given package and
+// branch: sha256(package + "@" + branch).
+func CacheKey(gomodule, branch string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s@%s", gomodule, branch)))
+
+	return fmt.Sprintf("%x", sum)
+}
+
+// CachedWorkDir returns the workdir gomason should use for gomodule at branch when
+// caching is enabled, creating it (and touching it, so it stays warm) if necessary.
+func CachedWorkDir(gomodule, branch string) (workdir string, err error) {
+	cacheRoot, err := CacheDir()
+	if err != nil {
+		return workdir, err
+	}
+
+	workdir = fmt.Sprintf("%s/%s", cacheRoot, CacheKey(gomodule, branch))
+
+	if err = os.MkdirAll(workdir, 0755); err != nil {
+		err = errors.Wrapf(err, "failed to create cached workdir %s", workdir)
+		return workdir, err
+	}
+
+	now := time.Now()
+
+	if err = os.Chtimes(workdir, now, now); err != nil {
+		err = errors.Wrapf(err, "failed to touch cached workdir %s", workdir)
+		return workdir, err
+	}
+
+	return workdir, err
+}
+
+// PurgeCache walks the cache root and removes any subdirectory whose mtime is older
+// than timeout, skipping the entry named keep (the one about to be reused, if any).
+func PurgeCache(timeout time.Duration, keep string) (err error) {
+	cacheRoot, err := CacheDir()
+	if err != nil {
+		return err
+	}
+
+	entries, err := ioutil.ReadDir(cacheRoot)
+	if err != nil {
+		err = errors.Wrapf(err, "failed to read cache dir %s", cacheRoot)
+		return err
+	}
+
+	cutoff := time.Now().Add(-timeout)
+
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == keep {
+			continue
+		}
+
+		if entry.ModTime().Before(cutoff) {
+			if err = os.RemoveAll(filepath.Join(cacheRoot, entry.Name())); err != nil {
+				err = errors.Wrapf(err, "failed to purge stale cache entry %s", entry.Name())
+				return err
+			}
+		}
+	}
+
+	return err
+}
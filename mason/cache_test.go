@@ -0,0 +1,16 @@
+package mason
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheKey(t *testing.T) {
+	key := CacheKey("github.com/nikogura/gomason", "master")
+
+	assert.Len(t, key, 64, "CacheKey should return a hex-encoded sha256 digest")
+	assert.Equal(t, key, CacheKey("github.com/nikogura/gomason", "master"), "CacheKey should be deterministic")
+	assert.NotEqual(t, key, CacheKey("github.com/nikogura/gomason", "develop"), "CacheKey should differ by branch")
+	assert.NotEqual(t, key, CacheKey("github.com/nikogura/other", "master"), "CacheKey should differ by package")
+}
@@ -0,0 +1,71 @@
+// Copyright © 2017 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/nikogura/gomason/mason"
+	"github.com/spf13/cobra"
+	"log"
+	"os"
+)
+
+var archiveFormat string
+var archiveSign bool
+
+// archiveCmd represents the archive command
+var archiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Bundle build targets into signed, checksummed archives.",
+	Long: `
+Bundle build targets into signed, checksummed archives.
+
+Runs 'test' and 'build', then packages each build target's binary (plus any extras) into a .zip or .tar.gz, writes a SHA256SUMS manifest, and optionally signs that manifest.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cwd, err := os.Getwd()
+		if err != nil {
+			log.Fatalf("Failed to get current working directory: %s", err)
+		}
+
+		meta, err := mason.ReadMetadata("metadata.json")
+		if err != nil {
+			log.Fatalf("Failed to read metadata.json: %s", err)
+		}
+
+		wd, err := resolveWorkDir(meta.Package)
+		if err != nil {
+			log.Fatalf("Failed to resolve workdir: %s", err)
+		}
+
+		buildmetadata, err := mason.WholeShebang(wd, branch, true, false, false, verbose)
+		if err != nil {
+			log.Fatalf("Error building package: %s", err)
+		}
+
+		err = mason.Archive(meta, buildmetadata.Path, cwd, archiveFormat, archiveSign, verbose)
+		if err != nil {
+			log.Fatalf("archive failed: %s", err)
+		}
+
+		log.Printf("Archives and SHA256SUMS manifest written successfully!\n\n")
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(archiveCmd)
+
+	archiveCmd.Flags().StringVar(&archiveFormat, "format", "", "Archive format to use (zip or tar.gz).  Defaults to zip on windows, tar.gz elsewhere.")
+	archiveCmd.Flags().BoolVar(&archiveSign, "sign", false, "Sign the SHA256SUMS manifest after archiving.")
+}
@@ -0,0 +1,96 @@
+// Copyright © 2017 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/nikogura/gomason/mason"
+	"github.com/spf13/cobra"
+	"log"
+	"time"
+)
+
+// cache, when set via --cache, routes ephemeral GOPATHs through a persistent,
+// content-addressed workdir under mason.CacheDir() instead of a throwaway temp dir.
+var cache bool
+
+// purgeTimeout overrides mason.DefaultPurgeTimeout for how old a cache entry can get
+// before it's considered stale and purged.
+var purgeTimeout time.Duration
+
+// cacheCmd is the parent for cache management subcommands.
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage gomason's persistent workdir cache.",
+	Long: `
+Manage gomason's persistent workdir cache.
+
+With --cache, gomason reuses a content-addressed workdir per package/branch instead of a throwaway temp dir, so repeated local runs skip redundant govendor sync and module downloads.
+`,
+}
+
+// cachePruneCmd removes stale cache entries on demand.
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete cache entries older than the purge timeout.",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := mason.PurgeCache(purgeTimeout, ""); err != nil {
+			log.Fatalf("Failed to prune cache: %s", err)
+		}
+
+		log.Printf("Cache pruned.\n\n")
+	},
+}
+
+// cachePathCmd prints the cache root, for scripting.
+var cachePathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Print the cache root directory.",
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := mason.CacheDir()
+		if err != nil {
+			log.Fatalf("Failed to resolve cache dir: %s", err)
+		}
+
+		fmt.Println(dir)
+	},
+}
+
+// resolveWorkDir returns the cached workdir for gomodule/branch when --cache is set and
+// no explicit --workdir was given, purging stale entries along the way.  Otherwise it
+// falls through to whatever --workdir the user passed (possibly empty, meaning "use a
+// throwaway temp dir").
+func resolveWorkDir(gomodule string) (dir string, err error) {
+	if !cache || workdir != "" {
+		return workdir, err
+	}
+
+	key := mason.CacheKey(gomodule, branch)
+
+	if err = mason.PurgeCache(purgeTimeout, key); err != nil {
+		log.Printf("Warning: failed to purge stale cache entries: %s", err)
+	}
+
+	return mason.CachedWorkDir(gomodule, branch)
+}
+
+func init() {
+	RootCmd.PersistentFlags().BoolVar(&cache, "cache", false, "Route ephemeral GOPATHs through a persistent, content-addressed workdir cache.")
+	RootCmd.PersistentFlags().DurationVar(&purgeTimeout, "purge-timeout", mason.DefaultPurgeTimeout, "How old a cache entry can get before it's considered stale and purged.")
+
+	cacheCmd.AddCommand(cachePruneCmd)
+	cacheCmd.AddCommand(cachePathCmd)
+	RootCmd.AddCommand(cacheCmd)
+}
@@ -0,0 +1,81 @@
+// Copyright © 2017 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/nikogura/gomason/mason"
+	"github.com/spf13/cobra"
+	"io/ioutil"
+	"log"
+	"os"
+)
+
+var debsrcKeyID string
+
+// debsrcCmd represents the debsrc command
+var debsrcCmd = &cobra.Command{
+	Use:   "debsrc",
+	Short: "Build a signed Debian source package.",
+	Long: `
+Build a signed Debian source package.
+
+Stages the checked out project under debian packaging conventions, renders debian/changelog, debian/control, debian/rules and debian/copyright from the 'debinfo' section of metadata.json, and signs the result with debuild.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		meta, err := mason.ReadMetadata("metadata.json")
+		if err != nil {
+			log.Fatalf("Failed to read metadata.json: %s", err)
+		}
+
+		workDir, err := resolveWorkDir(meta.Package)
+		if err != nil {
+			log.Fatalf("Failed to resolve workdir: %s", err)
+		}
+
+		if workDir == "" {
+			tempDir, tmpErr := ioutil.TempDir("", "gomason")
+			if tmpErr != nil {
+				log.Fatalf("Failed to create temp dir: %s", tmpErr)
+			}
+
+			defer os.RemoveAll(tempDir)
+
+			workDir = tempDir
+		}
+
+		gopath, err := mason.CreateGoPath(workDir)
+		if err != nil {
+			log.Fatalf("Failed to create ephemeral GOPATH: %s", err)
+		}
+
+		err = mason.Checkout(gopath, meta.Package, branch, verbose)
+		if err != nil {
+			log.Fatalf("failed to checkout package %s at branch %s: %s", meta.Package, branch, err)
+		}
+
+		dscPath, err := mason.Debsrc(meta, gopath, workDir, debsrcKeyID, verbose)
+		if err != nil {
+			log.Fatalf("debsrc failed: %s", err)
+		}
+
+		log.Printf("Debian source package built successfully: %s\n\n", dscPath)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(debsrcCmd)
+
+	debsrcCmd.Flags().StringVar(&debsrcKeyID, "keyid", "", "GPG key id to sign the source package with.  Defaults to the configured signing identity.")
+}
@@ -0,0 +1,53 @@
+// Copyright © 2017 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/nikogura/gomason/mason"
+	"github.com/spf13/cobra"
+	"log"
+)
+
+// matrixCmd represents the matrix command
+var matrixCmd = &cobra.Command{
+	Use:   "matrix",
+	Short: "Test your project against a matrix of Go toolchain versions.",
+	Long: `
+Test your project against a matrix of Go toolchain versions.
+
+Reads the 'test_matrix' list from metadata.json, downloads and verifies each Go SDK, and runs 'go test' against your code with each one, printing a pass/fail table at the end.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		meta, err := mason.ReadMetadata("metadata.json")
+		if err != nil {
+			log.Fatalf("Failed to read metadata.json: %s", err)
+		}
+
+		if len(meta.TestMatrix) == 0 {
+			log.Fatalf("No 'test_matrix' versions found in metadata.json")
+		}
+
+		err = mason.Matrix(meta, meta.TestMatrix, verbose)
+		if err != nil {
+			log.Fatalf("matrix testing failed: %s", err)
+		}
+
+		log.Printf("All Go versions passed!\n\n")
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(matrixCmd)
+}
@@ -32,7 +32,17 @@ Artists sign their work, you should too.
 Signing sorta implies something to sign, which in turn, implies that it built, which means it tested successfully.  What I'm getting at is this command will run 'test', 'build', and then it will 'sign'.
 `,
 	Run: func(cmd *cobra.Command, args []string) {
-		_, err := mason.WholeShebang(workdir, branch, true, true, false, verbose)
+		meta, err := mason.ReadMetadata("metadata.json")
+		if err != nil {
+			log.Fatalf("Failed to read metadata.json: %s", err)
+		}
+
+		wd, err := resolveWorkDir(meta.Package)
+		if err != nil {
+			log.Fatalf("Failed to resolve workdir: %s", err)
+		}
+
+		_, err = mason.WholeShebang(wd, branch, true, true, false, verbose)
 		if err != nil {
 			log.Fatalf("Error running sign: %s", err)
 		}
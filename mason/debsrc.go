@@ -0,0 +1,303 @@
+package mason
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// debChangelogTemplate is rendered into debian/changelog for the source package.  The
+// trailer line needs both a maintainer and a date - dpkg-parsechangelog/debuild refuse
+// to parse a changelog missing the date.
+const debChangelogTemplate = `%s (%s-1) unstable; urgency=medium
+
+  * Automated build via gomason.
+
+ -- %s  %s
+
+`
+
+// renderChangelog fills in debChangelogTemplate.  timestamp is passed in (rather than
+// computed here) so the rendering itself stays deterministic and testable; Debsrc
+// supplies time.Now().Format(time.RFC1123Z) for real runs.
+func renderChangelog(pkgName, version, maintainer, timestamp string) string {
+	return fmt.Sprintf(debChangelogTemplate, pkgName, version, maintainer, timestamp)
+}
+
+// debControlTemplate is rendered into debian/control for the source package.
+const debControlTemplate = `Source: %s
+Section: %s
+Priority: %s
+Maintainer: %s
+Build-Depends: %s
+Standards-Version: 3.9.8
+
+Package: %s
+Architecture: %s
+Description: %s
+`
+
+// debRulesTemplate is rendered into debian/rules for the source package.
+const debRulesTemplate = `#!/usr/bin/make -f
+%:
+	dh $@
+`
+
+// debCopyrightTemplate is rendered into debian/copyright for the source package.
+const debCopyrightTemplate = `Format: https://www.debian.org/doc/packaging-manuals/copyright-format/1.0/
+Upstream-Name: %s
+Source: %s
+
+Files: *
+Copyright: %s
+License: Apache-2.0
+`
+
+// Debsrc builds a signed Debian source package from the checked out project, staging
+// it under workdir/<pkg>-<version>/ and invoking debuild to produce and sign it with
+// signerKeyID.  It returns the path to the signed .dsc file debuild produces.
+func Debsrc(meta Metadata, gopath, workdir string, signerKeyID string, verbose bool) (dscPath string, err error) {
+	debInfo := meta.DebInfo
+
+	pkgName := filepath.Base(meta.Package)
+
+	stageDir := fmt.Sprintf("%s/%s-%s", workdir, pkgName, meta.Version)
+
+	if verbose {
+		log.Printf("Staging Debian source package in %s", stageDir)
+	}
+
+	srcDir := fmt.Sprintf("%s/src/%s", gopath, meta.Package)
+
+	if err = copyTree(srcDir, stageDir); err != nil {
+		err = errors.Wrapf(err, "failed to stage source tree in %s", stageDir)
+		return dscPath, err
+	}
+
+	// The .orig.tar.gz is supposed to be the pristine upstream source - it has to be
+	// snapshotted before debian/ exists in stageDir, or the packaging metadata ends up
+	// baked into what downstream tooling treats as upstream source.
+	origTarball := fmt.Sprintf("%s/%s_%s.orig.tar.gz", workdir, pkgName, meta.Version)
+
+	if verbose {
+		log.Printf("Synthesizing orig tarball %s", origTarball)
+	}
+
+	if err = tarGz(stageDir, origTarball); err != nil {
+		err = errors.Wrapf(err, "failed to create orig tarball %s", origTarball)
+		return dscPath, err
+	}
+
+	debianDir := fmt.Sprintf("%s/debian", stageDir)
+
+	if err = os.MkdirAll(debianDir, 0755); err != nil {
+		err = errors.Wrapf(err, "failed to create %s", debianDir)
+		return dscPath, err
+	}
+
+	changelog := renderChangelog(pkgName, meta.Version, debInfo.Maintainer, time.Now().Format(time.RFC1123Z))
+	control := fmt.Sprintf(debControlTemplate, pkgName, debInfo.Section, debInfo.Priority, debInfo.Maintainer, joinCommas(debInfo.BuildDepends), pkgName, joinSpaces(debInfo.Architectures), meta.Description)
+	copyright := fmt.Sprintf(debCopyrightTemplate, meta.Package, meta.GitPath, debInfo.Maintainer)
+
+	if err = ioutil.WriteFile(fmt.Sprintf("%s/changelog", debianDir), []byte(changelog), 0644); err != nil {
+		err = errors.Wrap(err, "failed to write debian/changelog")
+		return dscPath, err
+	}
+
+	if err = ioutil.WriteFile(fmt.Sprintf("%s/control", debianDir), []byte(control), 0644); err != nil {
+		err = errors.Wrap(err, "failed to write debian/control")
+		return dscPath, err
+	}
+
+	if err = ioutil.WriteFile(fmt.Sprintf("%s/rules", debianDir), []byte(debRulesTemplate), 0755); err != nil {
+		err = errors.Wrap(err, "failed to write debian/rules")
+		return dscPath, err
+	}
+
+	if err = ioutil.WriteFile(fmt.Sprintf("%s/copyright", debianDir), []byte(copyright), 0644); err != nil {
+		err = errors.Wrap(err, "failed to write debian/copyright")
+		return dscPath, err
+	}
+
+	// debuild -S re-invokes dpkg-source itself, so there's no separate dpkg-source -b
+	// step here - running both would build the unsigned .dsc twice and throw the first
+	// copy away.
+	debuild, err := exec.LookPath("debuild")
+	if err != nil {
+		err = errors.Wrap(err, "debuild not found on PATH.  Is it installed?")
+		return dscPath, err
+	}
+
+	signEntity := signerKeyID
+	if signEntity == "" {
+		// fall back to the same identity resolution SignBinary uses
+		signInfo := meta.SignInfo
+
+		config, cfgErr := GetUserConfig()
+		if cfgErr == nil && config.User.Email != "" {
+			signEntity = config.User.Email
+		} else {
+			signEntity = signInfo.Email
+		}
+	}
+
+	if signEntity == "" {
+		err = fmt.Errorf("cannot sign the source package without a GPG key id.  Pass one explicitly, set 'signing.email' in metadata.json, or create ~/.gomason")
+		return dscPath, err
+	}
+
+	debuildCmd := exec.Command(debuild, "-S", "-sa", fmt.Sprintf("-k%s", signEntity))
+	debuildCmd.Dir = stageDir
+	debuildCmd.Stdout = os.Stdout
+	debuildCmd.Stderr = os.Stderr
+
+	if verbose {
+		log.Printf("Running %s -S -sa -k%s in %s", debuild, signEntity, stageDir)
+	}
+
+	if err = debuildCmd.Run(); err != nil {
+		err = errors.Wrap(err, "debuild failed to sign the source package")
+		return dscPath, err
+	}
+
+	dscPath = fmt.Sprintf("%s/%s_%s.dsc", workdir, pkgName, meta.Version)
+
+	if _, statErr := os.Stat(dscPath); statErr != nil {
+		err = errors.Wrapf(statErr, "debuild reported success but the expected .dsc %s is missing", dscPath)
+		return dscPath, err
+	}
+
+	if verbose {
+		log.Printf("Debian source package built and signed successfully: %s\n\n", dscPath)
+	}
+
+	return dscPath, err
+}
+
+// copyTree copies the checked out source tree into the debian staging directory.
+func copyTree(src, dst string) (err error) {
+	return filepath.Walk(src, func(path string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dst, rel)
+
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode())
+		}
+
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		if err = os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode())
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+
+		_, err = io.Copy(out, in)
+		return err
+	})
+}
+
+// tarGz synthesizes a .orig.tar.gz from the staged source tree.  The tar and gzip
+// footers are only flushed on Close, so both Close errors are checked explicitly
+// rather than deferred - a swallowed flush error here would leave a corrupt tarball
+// on disk while reporting success.
+func tarGz(srcDir, destFile string) (err error) {
+	f, err := os.Create(destFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gzw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gzw)
+
+	err = filepath.Walk(srcDir, func(path string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+
+		rel, err := filepath.Rel(filepath.Dir(srcDir), path)
+		if err != nil {
+			return err
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = rel
+
+		if err = tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		_, err = io.Copy(tw, in)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = tw.Close(); err != nil {
+		return errors.Wrap(err, "failed to flush tar footer")
+	}
+
+	if err = gzw.Close(); err != nil {
+		return errors.Wrap(err, "failed to flush gzip footer")
+	}
+
+	return err
+}
+
+func joinCommas(items []string) string {
+	return joinWith(items, ", ")
+}
+
+func joinSpaces(items []string) string {
+	return joinWith(items, " ")
+}
+
+func joinWith(items []string, sep string) string {
+	out := ""
+	for i, item := range items {
+		if i > 0 {
+			out += sep
+		}
+		out += item
+	}
+	return out
+}
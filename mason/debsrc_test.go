@@ -0,0 +1,22 @@
+package mason
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJoinWith(t *testing.T) {
+	assert.Equal(t, "", joinWith(nil, ", "), "joining nothing should produce an empty string")
+	assert.Equal(t, "a", joinWith([]string{"a"}, ", "), "a single item needs no separator")
+	assert.Equal(t, "a, b, c", joinCommas([]string{"a", "b", "c"}), "joinCommas should separate with ', '")
+	assert.Equal(t, "a b c", joinSpaces([]string{"a", "b", "c"}), "joinSpaces should separate with ' '")
+}
+
+func TestRenderChangelogIncludesDate(t *testing.T) {
+	got := renderChangelog("widget", "1.2.3", "Jane Dev <jane@example.com>", "Wed, 01 Jan 2020 00:00:00 +0000")
+
+	want := "widget (1.2.3-1) unstable; urgency=medium\n\n  * Automated build via gomason.\n\n -- Jane Dev <jane@example.com>  Wed, 01 Jan 2020 00:00:00 +0000\n\n"
+
+	assert.Equal(t, want, got, "renderChangelog must include a trailing date or dpkg-parsechangelog/debuild will refuse to parse it")
+}
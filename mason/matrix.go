@@ -0,0 +1,244 @@
+package mason
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// sdkCacheDir returns the directory downloaded Go SDK tarballs are cached under.
+func sdkCacheDir() (dir string, err error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		err = errors.Wrap(err, "failed to determine home directory")
+		return dir, err
+	}
+
+	dir = fmt.Sprintf("%s/.cache/gomason/sdk", home)
+
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		err = errors.Wrapf(err, "failed to create SDK cache dir %s", dir)
+		return dir, err
+	}
+
+	return dir, err
+}
+
+// Matrix runs 'go test' against every Go toolchain version listed in versions,
+// downloading and verifying each SDK into an isolated, cached location, and returns
+// an error if any version's tests fail after printing a pass/fail table for all of them.
+func Matrix(meta Metadata, versions []string, verbose bool) (err error) {
+	results := make(map[string]error)
+
+	for _, version := range versions {
+		if verbose {
+			log.Printf("Running test matrix for Go %s\n", version)
+		}
+
+		sdkRoot, provisionErr := provisionSDK(meta, version, verbose)
+		if provisionErr != nil {
+			results[version] = errors.Wrapf(provisionErr, "failed to provision Go %s", version)
+			continue
+		}
+
+		workDir, tmpErr := ioutil.TempDir("", "gomason-matrix")
+		if tmpErr != nil {
+			results[version] = errors.Wrap(tmpErr, "failed to create temp dir")
+			continue
+		}
+
+		gopath, gopathErr := CreateGoPath(workDir)
+		if gopathErr != nil {
+			os.RemoveAll(workDir)
+			results[version] = errors.Wrap(gopathErr, "failed to create ephemeral GOPATH")
+			continue
+		}
+
+		if checkoutErr := Checkout(gopath, meta.Package, "", verbose); checkoutErr != nil {
+			os.RemoveAll(workDir)
+			results[version] = errors.Wrap(checkoutErr, "failed to checkout package")
+			continue
+		}
+
+		testErr := goTestWithSDK(gopath, meta.Package, sdkRoot, verbose)
+
+		os.RemoveAll(workDir)
+
+		results[version] = testErr
+	}
+
+	log.Printf("\nMatrix Results:\n")
+
+	failed := false
+
+	for _, version := range versions {
+		status := "PASS"
+		if results[version] != nil {
+			status = "FAIL"
+			failed = true
+		}
+
+		log.Printf("  go%-10s %s\n", version, status)
+
+		if results[version] != nil && verbose {
+			log.Printf("    %s\n", results[version])
+		}
+	}
+
+	if failed {
+		err = fmt.Errorf("one or more Go versions failed testing.  See table above for details")
+	}
+
+	return err
+}
+
+// provisionSDK downloads (if not already cached), verifies, and extracts the Go SDK
+// for the given version, returning the path to its GOROOT.
+func provisionSDK(meta Metadata, version string, verbose bool) (goroot string, err error) {
+	cacheDir, err := sdkCacheDir()
+	if err != nil {
+		return goroot, err
+	}
+
+	sdkDir := fmt.Sprintf("%s/go%s", cacheDir, version)
+	goroot = sdkDir
+
+	if _, statErr := os.Stat(fmt.Sprintf("%s/bin/go", sdkDir)); statErr == nil {
+		if verbose {
+			log.Printf("Go %s already provisioned at %s\n", version, sdkDir)
+		}
+
+		return goroot, err
+	}
+
+	platform := fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH)
+
+	expectedSHA, ok := meta.ToolchainSHA256[fmt.Sprintf("%s-%s-%s", version, runtime.GOOS, runtime.GOARCH)]
+	if !ok {
+		err = fmt.Errorf("no pinned SHA256 for go%s on %s.  Add one under 'toolchain_sha256' in metadata.json", version, platform)
+		return goroot, err
+	}
+
+	tarballName := fmt.Sprintf("go%s.%s-%s.tar.gz", version, runtime.GOOS, runtime.GOARCH)
+	tarballPath := fmt.Sprintf("%s/%s", cacheDir, tarballName)
+
+	if !tarballMatchesSHA(tarballPath, expectedSHA) {
+		if verbose {
+			log.Printf("Downloading %s\n", tarballName)
+		}
+
+		if err = downloadFile(fmt.Sprintf("https://go.dev/dl/%s", tarballName), tarballPath); err != nil {
+			err = errors.Wrapf(err, "failed to download %s", tarballName)
+			return goroot, err
+		}
+
+		if !tarballMatchesSHA(tarballPath, expectedSHA) {
+			err = fmt.Errorf("checksum mismatch for %s: expected %s", tarballName, expectedSHA)
+			return goroot, err
+		}
+	}
+
+	if err = os.MkdirAll(sdkDir, 0755); err != nil {
+		err = errors.Wrapf(err, "failed to create SDK dir %s", sdkDir)
+		return goroot, err
+	}
+
+	tar, err := exec.LookPath("tar")
+	if err != nil {
+		err = errors.Wrap(err, "tar not found on PATH")
+		return goroot, err
+	}
+
+	cmd := exec.Command(tar, "-xzf", tarballPath, "-C", sdkDir, "--strip-components=1")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err = cmd.Run(); err != nil {
+		err = errors.Wrapf(err, "failed to extract %s", tarballPath)
+		return goroot, err
+	}
+
+	return goroot, err
+}
+
+// tarballMatchesSHA reports whether the file at path exists and matches the given hex
+// encoded SHA256 digest.
+func tarballMatchesSHA(path, expectedSHA string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+
+	if _, err = io.Copy(hasher, f); err != nil {
+		return false
+	}
+
+	return fmt.Sprintf("%x", hasher.Sum(nil)) == expectedSHA
+}
+
+// downloadFile downloads url and writes it to destPath.
+func downloadFile(url, destPath string) (err error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status downloading %s: %s", url, resp.Status)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return err
+	}
+
+	out, err := os.Create(destPath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, resp.Body)
+
+	return err
+}
+
+// goTestWithSDK runs 'go test -v ./...' in the checked out code directory using the Go
+// toolchain rooted at sdkRoot rather than whatever 'go' is on PATH.
+func goTestWithSDK(gopath, gomodule, sdkRoot string, verbose bool) (err error) {
+	wd := fmt.Sprintf("%s/src/%s", gopath, gomodule)
+
+	if verbose {
+		log.Printf("Changing working directory to %s.\n", wd)
+	}
+
+	if err = os.Chdir(wd); err != nil {
+		log.Printf("Error changing working dir to %q: %s", wd, err)
+		return err
+	}
+
+	gocommand := fmt.Sprintf("%s/bin/go", sdkRoot)
+
+	cmd := exec.Command(gocommand, "test", "-v", "./...")
+
+	runenv := append(os.Environ(), fmt.Sprintf("GOPATH=%s", gopath), fmt.Sprintf("GOROOT=%s", sdkRoot), fmt.Sprintf("PATH=%s/bin:%s", sdkRoot, os.Getenv("PATH")))
+
+	cmd.Env = runenv
+
+	output, err := cmd.CombinedOutput()
+
+	log.Printf(string(output))
+
+	return err
+}
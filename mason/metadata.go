@@ -0,0 +1,50 @@
+package mason
+
+// Metadata models metadata.json, the per-project configuration file gomason reads
+// its build, sign, and test instructions from.
+type Metadata struct {
+	Package      string   `json:"package"`
+	Version      string   `json:"version"`
+	Description  string   `json:"description,omitempty"`
+	BuildTargets []string `json:"build_targets,omitempty"`
+	// BuildExtras lists extra files (LICENSE, README, config templates, ...) that
+	// Archive bundles into every target's archive alongside its binary.  Paths are
+	// resolved relative to the archive outDir, same as the build target binaries.
+	BuildExtras []string               `json:"build_extras,omitempty"`
+	SignInfo    SignInfo               `json:"signing,omitempty"`
+	DebInfo     DebInfo                `json:"debinfo,omitempty"`
+	Options     map[string]interface{} `json:"options,omitempty"`
+	// TestMatrix lists the Go toolchain versions (e.g. "1.21.0") Matrix should test
+	// against.
+	TestMatrix []string `json:"test_matrix,omitempty"`
+	// ToolchainSHA256 pins the expected SHA256 digest of each Go SDK tarball Matrix
+	// downloads, keyed by "<version>-<os>-<arch>" (e.g. "1.21.0-linux-amd64").
+	ToolchainSHA256 map[string]string `json:"toolchain_sha256,omitempty"`
+	WorkDir         string            `json:"-"`
+	Path            string            `json:"-"`
+	GitPath         string            `json:"-"`
+}
+
+// SignInfo holds the signing identity and backend selection read out of metadata.json's
+// 'signing' section, possibly overridden by ~/.gomason.
+type SignInfo struct {
+	Program string `json:"program,omitempty"`
+	Email   string `json:"email,omitempty"`
+	// Signer selects the Signer implementation (e.g. "gpg", "minisign", "cosign") used
+	// by SignBinary/VerifyBinary.  When empty, Program is used, then defaultSigningProgram.
+	// SignBinary lets a program set in ~/.gomason override it.
+	Signer string `json:"signer,omitempty"`
+	// KeyPath is the signing key gomason hands to non-gpg backends (minisign's secret
+	// key file, cosign's --key).  Email remains the identity gpg signs with.
+	KeyPath string `json:"keypath,omitempty"`
+}
+
+// DebInfo holds the Debian packaging metadata Debsrc renders debian/changelog,
+// debian/control and debian/copyright from.
+type DebInfo struct {
+	Maintainer    string   `json:"maintainer,omitempty"`
+	Section       string   `json:"section,omitempty"`
+	Priority      string   `json:"priority,omitempty"`
+	BuildDepends  []string `json:"build_depends,omitempty"`
+	Architectures []string `json:"architectures,omitempty"`
+}
@@ -3,60 +3,125 @@ package mason
 import (
 	"fmt"
 	"github.com/pkg/errors"
+	"io/ioutil"
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
 )
 
 // It's a good default.  You can install it anywhere.
 const defaultSigningProgram = "gpg"
 
+// Signer is the interface implemented by every signing backend gomason knows how to drive.
+// Name returns the identifier used in metadata.json's 'signing.signer' field to select it.
+type Signer interface {
+	Name() string
+	Sign(binary, entity string, meta Metadata) (sigPath string, err error)
+	Verify(binary string, meta Metadata) (ok bool, err error)
+}
+
+// signerRegistry holds every Signer available to SignBinary/VerifyBinary, keyed by Name().
+var signerRegistry = make(map[string]Signer)
+
+// RegisterSigner adds a Signer to the registry SignBinary/VerifyBinary consult, keyed by
+// its Name().  Built-in signers are registered this way at package init time, so callers
+// can override a built-in by registering their own Signer under the same name.
+func RegisterSigner(s Signer) {
+	signerRegistry[s.Name()] = s
+}
+
+func init() {
+	RegisterSigner(gpgSigner{})
+	RegisterSigner(minisignSigner{})
+	RegisterSigner(cosignSigner{})
+}
+
 // SignBinary  signs the given binary based on the entity and program given in metadata.json, possibly overridden by information in ~/.gomason
 func SignBinary(meta Metadata, binary string, verbose bool) (err error) {
+	// Unattended CI signing shouldn't require a pre-provisioned ~/.gnupg - import
+	// whatever key GOMASON_GPG_KEY carries into an ephemeral keyring before resolving
+	// the signer, so it's a no-op everywhere else. ImportKeys itself no-ops when
+	// GOMASON_GPG_KEY isn't set.
+	importDir := meta.WorkDir
+	if importDir == "" {
+		importDir = filepath.Dir(binary)
+	}
+
+	if err = ImportKeys(importDir, os.Environ()); err != nil {
+		err = errors.Wrap(err, "failed to import CI signing key")
+		return err
+	}
 
 	// pull signing info out of metadata.json
 	signInfo := meta.SignInfo
 
-	signProg := signInfo.Program
+	signProg := signInfo.Signer
+	if signProg == "" {
+		signProg = signInfo.Program
+	}
 	if signProg == "" {
 		signProg = defaultSigningProgram
 	}
 
-	signEntity := signInfo.Email
-
 	config, err := GetUserConfig()
 	if err != nil {
 		err = errors.Wrapf(err, "failed to get per-user config from ~/.gomason")
 	}
 
-	// email from .gomason overrides metadata
-	if config.User.Email != "" {
-		signEntity = config.User.Email
-	}
-
 	// program from .gomason overrides metadata
 	if config.Signing.Program != "" {
 		signProg = config.Signing.Program
 	}
 
-	if signEntity == "" {
-		err = fmt.Errorf("Cannot sign without a signing entity (email).\n\nSet 'signing' section in metadata.json, or create ~/.gomason with the appropriate content.\n\nSee https://github.com/nikogura/gomason#config-reference for details.\n\n")
+	// gpg identifies the key by email; minisign/cosign (and anything else registered)
+	// identify it by a key file path, so each backend gets its own entity source.
+	var signEntity string
+
+	if signProg == "gpg" {
+		signEntity = signInfo.Email
 
+		// email from .gomason overrides metadata
+		if config.User.Email != "" {
+			signEntity = config.User.Email
+		}
+
+		if signEntity == "" {
+			err = fmt.Errorf("Cannot sign without a signing entity (email).\n\nSet 'signing' section in metadata.json, or create ~/.gomason with the appropriate content.\n\nSee https://github.com/nikogura/gomason#config-reference for details.\n\n")
+
+			return err
+		}
+	} else {
+		signEntity = signInfo.KeyPath
+
+		// key path from .gomason overrides metadata
+		if config.Signing.KeyPath != "" {
+			signEntity = config.Signing.KeyPath
+		}
+
+		// minisign always needs a key file; cosign can sign keylessly via OIDC with no
+		// entity at all, so only minisign is refused an empty signEntity here.
+		if signEntity == "" && signProg == "minisign" {
+			err = fmt.Errorf("Cannot sign with minisign without a secret key path.\n\nSet 'signing.keypath' in metadata.json, or create ~/.gomason with the appropriate content.\n\nSee https://github.com/nikogura/gomason#config-reference for details.\n\n")
+
+			return err
+		}
+	}
+
+	signer, ok := signerRegistry[signProg]
+	if !ok {
+		err = fmt.Errorf("no signer registered under the name %q.  Is it a typo, or does it need to be registered with RegisterSigner?", signProg)
 		return err
 	}
 
 	if verbose {
-		log.Printf("Signing %s with identity %s.", binary, signEntity)
+		log.Printf("Signing %s with identity %s via %q.", binary, signEntity, signer.Name())
 	}
 
-	switch signProg {
-	// insert other signing types here
-	default:
-		err = SignGPG(binary, signEntity, meta)
-		if err != nil {
-			err = errors.Wrap(err, fmt.Sprintf("failed to run %q", signProg))
-			return err
-		}
+	_, err = signer.Sign(binary, signEntity, meta)
+	if err != nil {
+		err = errors.Wrap(err, fmt.Sprintf("failed to run %q", signer.Name()))
+		return err
 	}
 
 	return err
@@ -67,23 +132,134 @@ func VerifyBinary(binary string, meta Metadata) (ok bool, err error) {
 	// pull signing info out of metadata.json
 	signInfo := meta.SignInfo
 
-	signProg := signInfo.Program
+	signProg := signInfo.Signer
+	if signProg == "" {
+		signProg = signInfo.Program
+	}
 	if signProg == "" {
 		signProg = defaultSigningProgram
 	}
-	switch signProg {
-	// insert other signing types here
-	default:
-		ok, err = VerifyGPG(binary, meta)
-		if err != nil {
-			err = errors.Wrap(err, fmt.Sprintf("failed to run %q", signProg))
-			return ok, err
-		}
+
+	signer, registered := signerRegistry[signProg]
+	if !registered {
+		err = fmt.Errorf("no signer registered under the name %q.  Is it a typo, or does it need to be registered with RegisterSigner?", signProg)
+		return ok, err
+	}
+
+	ok, err = signer.Verify(binary, meta)
+	if err != nil {
+		err = errors.Wrap(err, fmt.Sprintf("failed to run %q", signer.Name()))
+		return ok, err
 	}
 
 	return ok, err
 }
 
+// ImportKeys reads an armored private key out of GOMASON_GPG_KEY, imports it into an
+// ephemeral keyring under workdir and points GNUPGHOME at that keyring, so unattended CI
+// signing doesn't require a pre-provisioned ~/.gnupg.  A GOMASON_GPG_PASSPHRASE in env
+// is only copied into this process's environment; it is not imported into the keyring
+// and is not passed to gpg.  It's a no-op for any name it doesn't recognize.
+func ImportKeys(workdir string, env []string) (err error) {
+	lookup := make(map[string]string)
+
+	for _, e := range env {
+		parts := splitEnv(e)
+		if len(parts) == 2 {
+			lookup[parts[0]] = parts[1]
+		}
+	}
+
+	key, ok := lookup["GOMASON_GPG_KEY"]
+	if !ok || key == "" {
+		// nothing to import
+		return err
+	}
+
+	keyringDir := fmt.Sprintf("%s/gnupg", workdir)
+
+	if err = os.MkdirAll(keyringDir, 0700); err != nil {
+		err = errors.Wrap(err, "failed to create ephemeral keyring directory")
+		return err
+	}
+
+	keyFile := fmt.Sprintf("%s/import.key", keyringDir)
+
+	if err = ioutil.WriteFile(keyFile, []byte(key), 0600); err != nil {
+		err = errors.Wrap(err, "failed to write armored key to disk for import")
+		return err
+	}
+
+	gpg, err := exec.LookPath("gpg")
+	if err != nil {
+		err = errors.Wrap(err, "can't find 'gpg' in path.  Is it installed?")
+		return err
+	}
+
+	cmd := exec.Command(gpg, "--homedir", keyringDir, "--batch", "--import", keyFile)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	importErr := cmd.Run()
+
+	// The key material is only ever needed on disk for gpg --import to read it - wipe it
+	// immediately afterwards, whether or not the import succeeded, so a persistent or
+	// reused cache workdir never retains a plaintext private key.
+	if removeErr := os.Remove(keyFile); removeErr != nil && !os.IsNotExist(removeErr) {
+		log.Printf("Warning: failed to remove imported key material %s: %s", keyFile, removeErr)
+	}
+
+	if importErr != nil {
+		err = errors.Wrap(importErr, "failed to import GPG key into ephemeral keyring")
+		return err
+	}
+
+	if passphrase, ok := lookup["GOMASON_GPG_PASSPHRASE"]; ok && passphrase != "" {
+		if err = os.Setenv("GOMASON_GPG_PASSPHRASE", passphrase); err != nil {
+			err = errors.Wrap(err, "failed to set GOMASON_GPG_PASSPHRASE")
+			return err
+		}
+	}
+
+	if err = os.Setenv("GNUPGHOME", keyringDir); err != nil {
+		err = errors.Wrap(err, "failed to point GNUPGHOME at the ephemeral keyring")
+		return err
+	}
+
+	return err
+}
+
+func splitEnv(e string) []string {
+	for i := 0; i < len(e); i++ {
+		if e[i] == '=' {
+			return []string{e[:i], e[i+1:]}
+		}
+	}
+	return []string{e}
+}
+
+// gpgSigner is the default Signer, preserving gomason's original gpg-based behavior.
+type gpgSigner struct{}
+
+func (g gpgSigner) Name() string {
+	return "gpg"
+}
+
+func (g gpgSigner) Sign(binary, entity string, meta Metadata) (sigPath string, err error) {
+	err = SignGPG(binary, entity, meta)
+	if err != nil {
+		return sigPath, err
+	}
+
+	sigPath = fmt.Sprintf("%s.asc", binary)
+
+	return sigPath, err
+}
+
+func (g gpgSigner) Verify(binary string, meta Metadata) (ok bool, err error) {
+	return VerifyGPG(binary, meta)
+}
+
 // SignGPG signs a given binary with GPG using the given signing entity.
 func SignGPG(binary string, signingEntity string, meta Metadata) (err error) {
 	shellCmd, err := exec.LookPath("gpg")
@@ -157,3 +333,141 @@ func VerifyGPG(binary string, meta Metadata) (ok bool, err error) {
 
 	return ok, err
 }
+
+// minisignSigner signs and verifies using the minisign tool.
+type minisignSigner struct{}
+
+func (m minisignSigner) Name() string {
+	return "minisign"
+}
+
+func (m minisignSigner) Sign(binary, entity string, meta Metadata) (sigPath string, err error) {
+	shellCmd, err := exec.LookPath("minisign")
+	if err != nil {
+		err = errors.Wrap(err, "can't find signing program 'minisign' in path.  Is it installed?")
+		return sigPath, err
+	}
+
+	sigPath = fmt.Sprintf("%s.minisig", binary)
+
+	keyFile := entity
+	if keyFile == "" {
+		keyFile = filepath.Join(os.Getenv("HOME"), ".minisign", "minisign.key")
+	}
+
+	cmd := exec.Command(shellCmd, "-S", "-s", keyFile, "-m", binary, "-x", sigPath)
+	cmd.Stdout = os.Stdout
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+
+	if err = cmd.Run(); err != nil {
+		err = errors.Wrap(err, fmt.Sprintf("failed to run %q", shellCmd))
+		return sigPath, err
+	}
+
+	return sigPath, err
+}
+
+func (m minisignSigner) Verify(binary string, meta Metadata) (ok bool, err error) {
+	shellCmd, err := exec.LookPath("minisign")
+	if err != nil {
+		err = errors.Wrap(err, "can't find signing program 'minisign' in path.  Is it installed?")
+		return ok, err
+	}
+
+	sigFile := fmt.Sprintf("%s.minisig", binary)
+
+	cmd := exec.Command(shellCmd, "-V", "-p", meta.SignInfo.Email, "-m", binary, "-x", sigFile)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+
+	if err = cmd.Run(); err != nil {
+		log.Printf("Verification Error: %s", err)
+		return ok, err
+	}
+
+	ok = true
+
+	return ok, err
+}
+
+// cosignSigner signs and verifies using sigstore's cosign, supporting both keyless
+// OIDC signing and key-based signing, producing a .sig+.pem bundle in the keyless case.
+type cosignSigner struct{}
+
+func (c cosignSigner) Name() string {
+	return "cosign"
+}
+
+func (c cosignSigner) Sign(binary, entity string, meta Metadata) (sigPath string, err error) {
+	shellCmd, err := exec.LookPath("cosign")
+	if err != nil {
+		err = errors.Wrap(err, "can't find signing program 'cosign' in path.  Is it installed?")
+		return sigPath, err
+	}
+
+	sigPath = fmt.Sprintf("%s.sig", binary)
+
+	args := []string{"sign-blob", "--yes", "--output-signature", sigPath}
+
+	if entity != "" {
+		// key-based signing
+		args = append(args, "--key", entity)
+	} else {
+		// keyless OIDC signing - also capture the signing certificate
+		certPath := fmt.Sprintf("%s.pem", binary)
+		args = append(args, "--output-certificate", certPath)
+	}
+
+	args = append(args, binary)
+
+	cmd := exec.Command(shellCmd, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+
+	if err = cmd.Run(); err != nil {
+		err = errors.Wrap(err, fmt.Sprintf("failed to run %q", shellCmd))
+		return sigPath, err
+	}
+
+	return sigPath, err
+}
+
+func (c cosignSigner) Verify(binary string, meta Metadata) (ok bool, err error) {
+	shellCmd, err := exec.LookPath("cosign")
+	if err != nil {
+		err = errors.Wrap(err, "can't find signing program 'cosign' in path.  Is it installed?")
+		return ok, err
+	}
+
+	sigFile := fmt.Sprintf("%s.sig", binary)
+
+	args := []string{"verify-blob", "--signature", sigFile}
+
+	if key, kok := meta.Options["cosign-key"]; kok {
+		args = append(args, "--key", key.(string))
+	} else {
+		certPath := fmt.Sprintf("%s.pem", binary)
+		args = append(args, "--certificate", certPath)
+	}
+
+	args = append(args, binary)
+
+	cmd := exec.Command(shellCmd, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+
+	if err = cmd.Run(); err != nil {
+		log.Printf("Verification Error: %s", err)
+		return ok, err
+	}
+
+	ok = true
+
+	return ok, err
+}
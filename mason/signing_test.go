@@ -0,0 +1,20 @@
+package mason
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportKeysNoopsWithoutKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gomason-importkeys")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.Nil(t, ImportKeys(dir, []string{"UNRELATED=value"}), "ImportKeys should no-op when GOMASON_GPG_KEY isn't set")
+
+	_, statErr := os.Stat(dir + "/gnupg")
+	assert.True(t, os.IsNotExist(statErr), "ImportKeys shouldn't create an ephemeral keyring when there's nothing to import")
+}
@@ -1,12 +1,15 @@
 package gomason
 
 import (
+	"context"
 	"fmt"
 	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
 	"io/ioutil"
 	"log"
 	"os"
 	"os/exec"
+	"strings"
 )
 
 // GoxInstall Installs github.com/mitchellh/gox, the go cross compiler
@@ -87,65 +90,357 @@ func Build(gopath string, meta Metadata, branch string, verbose bool) (err error
 	}
 
 	for _, target := range md.BuildInfo.Targets {
-		if verbose {
-			log.Printf("Building target: %q\n", target.Name)
+		err = buildTarget(gopath, gox, target, md, verbose)
+		if err != nil {
+			return err
 		}
+	}
+
+	err = BuildExtras(md, wd, verbose)
+	if err != nil {
+		err = errors.Wrapf(err, "Failed to build extras")
+		return err
+
+	}
 
-		// This gets weird because go's exec shell doesn't like the arg format that gox expects
-		// Building it thusly keeps the various quoting levels straight
+	return err
+}
 
-		gopathenv := fmt.Sprintf("GOPATH=%s", gopath)
-		runenv := append(os.Environ(), gopathenv)
+// BuildParallel is Build's fan-out counterpart: it builds each of meta's BuildInfo.Targets
+// concurrently, bounded by a semaphore of width parallelism, cancelling the remaining
+// targets on the first failure (builds already running are left to finish).  A
+// parallelism of 1 or less behaves like Build.
+func BuildParallel(gopath string, meta Metadata, branch string, parallelism int, verbose bool) (err error) {
+	if verbose {
+		log.Printf("Checking to see that gox is installed.\n")
+	}
 
-		cgo := ""
-		// build with cgo if we're told to do so.
-		if target.Cgo {
-			cgo = " -cgo"
+	if _, err := os.Stat(fmt.Sprintf("%s/go/bin/gox", gopath)); os.IsNotExist(err) {
+		err = GoxInstall(gopath, verbose)
+		if err != nil {
+			err = errors.Wrap(err, "Failed to install gox")
+			return err
 		}
+	}
 
-		for k, v := range target.Flags {
-			runenv = append(runenv, fmt.Sprintf("%s=%s", k, v))
-			if verbose {
-				log.Printf("Build Flag: %s=%s", k, v)
-			}
+	if _, err := os.Stat(fmt.Sprintf("%s/%s/metadata.json", gopath, meta.Package)); os.IsNotExist(err) {
+		err = Checkout(gopath, meta, branch, verbose)
+		if err != nil {
+			err = errors.Wrap(err, fmt.Sprintf("Failed to checkout module: %s branch: %s ", meta.Package, branch))
+			return err
 		}
+	}
 
-		args := gox + cgo + ` -osarch="` + target.Name + `"` + " ./..."
+	wd := fmt.Sprintf("%s/src/%s", gopath, meta.Package)
 
-		// Calling it through sh makes everything happy
-		cmd := exec.Command("sh", "-c", args)
+	if err = os.Chdir(wd); err != nil {
+		log.Printf("Error changing working dir to %q: %s", wd, err)
+		return err
+	}
 
-		cmd.Env = runenv
+	gox := fmt.Sprintf("%s/bin/gox", gopath)
 
-		if verbose {
-			log.Printf("Running gox with: %s", args)
-		}
+	metadatapath := fmt.Sprintf("%s/src/%s/metadata.json", gopath, meta.Package)
 
-		out, err := cmd.CombinedOutput()
+	md, err := ReadMetadata(metadatapath)
+	if err != nil {
+		err = errors.Wrap(err, "Failed to read metadata.json from checked out code")
+		return err
+	}
 
-		log.Printf("%s\n", string(out))
+	if parallelism < 1 {
+		parallelism = 1
+	}
 
-		if err != nil {
-			log.Printf("Build error: %s\n", err.Error())
-			return err
+	sem := make(chan struct{}, parallelism)
+
+	eg, ctx := errgroup.WithContext(context.Background())
+
+	for _, target := range md.BuildInfo.Targets {
+		target := target
+
+		eg.Go(func() error {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			defer func() { <-sem }()
+
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			return buildTarget(gopath, gox, target, md, verbose)
+		})
+	}
+
+	if err = eg.Wait(); err != nil {
+		return err
+	}
+
+	err = BuildExtras(md, wd, verbose)
+	if err != nil {
+		err = errors.Wrapf(err, "Failed to build extras")
+		return err
+	}
+
+	return err
+}
+
+// buildTarget runs gox for a single BuildTarget, honoring its cgo, buildmode, tags,
+// ldflags and environment knobs.  c-archive and c-shared targets bypass gox entirely,
+// since gox doesn't understand non-executable buildmodes.
+func buildTarget(gopath, gox string, target BuildTarget, md Metadata, verbose bool) (err error) {
+	if target.Buildmode == "c-archive" || target.Buildmode == "c-shared" {
+		return buildCTarget(gopath, target, md, verbose)
+	}
+
+	if verbose {
+		log.Printf("Building target: %q\n", target.Name)
+	}
+
+	// This gets weird because go's exec shell doesn't like the arg format that gox expects
+	// Building it thusly keeps the various quoting levels straight
+
+	gopathenv := fmt.Sprintf("GOPATH=%s", gopath)
+	runenv := append(os.Environ(), gopathenv)
+
+	cgo := ""
+	// build with cgo if we're told to do so.
+	if target.Cgo {
+		cgo = " -cgo"
+
+		archparts := strings.Split(target.Name, "/")
+
+		if len(archparts) == 2 && archparts[0] == "darwin" {
+			sdkEnv, err := macOSCgoEnv(target, verbose)
+			if err != nil {
+				return errors.Wrap(err, "Failed to resolve macOS SDK for cgo build")
+			}
+
+			runenv = append(runenv, sdkEnv...)
+		}
+
+		if cc, ok := md.CrossToolchains[target.Name]; ok {
+			runenv = append(runenv, fmt.Sprintf("CC=%s", cc.CC), fmt.Sprintf("CXX=%s", cc.CXX))
+
+			if verbose {
+				log.Printf("Cross toolchain for %s: CC=%s CXX=%s", target.Name, cc.CC, cc.CXX)
+			}
 		}
+	}
+
+	if target.CgoCFlags != "" {
+		runenv = append(runenv, fmt.Sprintf("CGO_CFLAGS=%s", target.CgoCFlags))
+	}
 
+	if target.CgoLDFlags != "" {
+		runenv = append(runenv, fmt.Sprintf("CGO_LDFLAGS=%s", target.CgoLDFlags))
+	}
+
+	for k, v := range target.Flags {
+		runenv = append(runenv, fmt.Sprintf("%s=%s", k, v))
 		if verbose {
-			log.Printf("Gox build complete and successful.\n\n")
+			log.Printf("Build Flag: %s=%s", k, v)
 		}
+	}
 
+	buildmode := ""
+	if target.Buildmode != "" {
+		buildmode = ` -buildmode=` + target.Buildmode
 	}
 
-	err = BuildExtras(md, wd, verbose)
+	tags := ""
+	if len(target.Tags) > 0 {
+		tags = ` -tags="` + strings.Join(target.Tags, " ") + `"`
+	}
+
+	ldflags := ""
+	if target.Ldflags != "" {
+		ldflags = ` -ldflags="` + target.Ldflags + `"`
+	}
+
+	args := gox + cgo + buildmode + tags + ldflags + ` -osarch="` + target.Name + `"` + " ./..."
+
+	// Calling it through sh makes everything happy
+	cmd := exec.Command("sh", "-c", args)
+
+	cmd.Env = runenv
+
+	if verbose {
+		log.Printf("Running gox with: %s", args)
+	}
+
+	out, err := cmd.CombinedOutput()
+
+	log.Printf("%s\n", string(out))
+
 	if err != nil {
-		err = errors.Wrapf(err, "Failed to build extras")
+		log.Printf("Build error: %s\n", err.Error())
 		return err
+	}
+
+	if verbose {
+		log.Printf("Gox build complete and successful.\n\n")
+	}
+
+	return err
+}
+
+// buildCTarget builds a c-archive or c-shared target directly with 'go build', bypassing
+// gox, and collects the generated header file alongside the archive/shared library so
+// downstream C consumers can link against it.
+func buildCTarget(gopath string, target BuildTarget, md Metadata, verbose bool) (err error) {
+	archparts := strings.Split(target.Name, "/")
+	if len(archparts) != 2 {
+		return fmt.Errorf("invalid build target %q, expected '<os>/<arch>'", target.Name)
+	}
+
+	osname := archparts[0]
+	archname := archparts[1]
+
+	parts := strings.Split(md.Package, "/")
+	binaryPrefix := parts[len(parts)-1]
+
+	libExt := cArtifactExtension(target.Buildmode, osname)
+
+	outputName := fmt.Sprintf("%s_%s_%s.%s", binaryPrefix, osname, archname, libExt)
+	headerName := fmt.Sprintf("%s_%s_%s.h", binaryPrefix, osname, archname)
+
+	gocommand, err := exec.LookPath("go")
+	if err != nil {
+		return errors.Wrap(err, "Failed to find go binary")
+	}
+
+	cmd := exec.Command(gocommand, "build", fmt.Sprintf("-buildmode=%s", target.Buildmode), "-o", outputName, "./...")
+
+	runenv := append(os.Environ(), fmt.Sprintf("GOPATH=%s", gopath), fmt.Sprintf("GOOS=%s", osname), fmt.Sprintf("GOARCH=%s", archname), "CGO_ENABLED=1")
+
+	if cc, ok := md.CrossToolchains[target.Name]; ok {
+		runenv = append(runenv, fmt.Sprintf("CC=%s", cc.CC), fmt.Sprintf("CXX=%s", cc.CXX))
+	}
+
+	if target.CgoCFlags != "" {
+		runenv = append(runenv, fmt.Sprintf("CGO_CFLAGS=%s", target.CgoCFlags))
+	}
+
+	if target.CgoLDFlags != "" {
+		runenv = append(runenv, fmt.Sprintf("CGO_LDFLAGS=%s", target.CgoLDFlags))
+	}
+
+	cmd.Env = runenv
 
+	if verbose {
+		log.Printf("Running: go build -buildmode=%s -o %s ./...", target.Buildmode, outputName)
+	}
+
+	out, err := cmd.CombinedOutput()
+
+	log.Printf("%s\n", string(out))
+
+	if err != nil {
+		return errors.Wrapf(err, "failed to build %s buildmode target %s", target.Buildmode, target.Name)
+	}
+
+	if _, err = os.Stat(headerName); os.IsNotExist(err) {
+		return errors.Wrapf(err, "expected header %s was not produced alongside %s", headerName, outputName)
+	}
+
+	if osname == "windows" {
+		defFile := outputName + ".def"
+
+		if _, defErr := os.Stat(defFile); defErr == nil {
+			if dlltool, dlltoolErr := exec.LookPath("dlltool"); dlltoolErr == nil {
+				importLib := fmt.Sprintf("%s_%s_%s.lib", binaryPrefix, osname, archname)
+
+				dlltoolCmd := exec.Command(dlltool, "--dllname", outputName, "--input-def", defFile, "--output-lib", importLib)
+
+				if verbose {
+					log.Printf("Running dlltool to produce import library %s", importLib)
+				}
+
+				if out, dlltoolErr = dlltoolCmd.CombinedOutput(); dlltoolErr != nil {
+					log.Printf("%s\n", string(out))
+					return errors.Wrap(dlltoolErr, "dlltool failed to produce the import library")
+				}
+			} else if verbose {
+				log.Printf("dlltool not found on PATH; skipping import library generation for %s", outputName)
+			}
+		} else if verbose {
+			// 'go build -buildmode=c-shared' doesn't emit a .def itself, so unless
+			// something upstream (e.g. a custom generator) dropped one next to the DLL,
+			// there's nothing for dlltool to consume - skip rather than fail the build.
+			log.Printf("No %s found; skipping import library generation for %s", defFile, outputName)
+		}
+	}
+
+	if verbose {
+		log.Printf("%s buildmode build complete and successful.\n\n", target.Buildmode)
 	}
 
 	return err
 }
 
+// cArtifactExtension returns the file extension gomason expects 'go build' to produce
+// for the given buildmode/OS combination.
+func cArtifactExtension(buildmode, osname string) string {
+	if buildmode == "c-archive" {
+		return "a"
+	}
+
+	switch osname {
+	case "windows":
+		return "dll"
+	case "darwin":
+		return "dylib"
+	default:
+		return "so"
+	}
+}
+
+// macOSCgoEnv resolves the SDK path for target.MacOSSDK via xcrun and returns the
+// SDKROOT/CGO_CFLAGS/CGO_LDFLAGS environment entries needed to cgo-build for target.MacOSArch.
+func macOSCgoEnv(target BuildTarget, verbose bool) (env []string, err error) {
+	sdk := target.MacOSSDK
+	if sdk == "" {
+		sdk = "macosx"
+	}
+
+	xcrun, err := exec.LookPath("xcrun")
+	if err != nil {
+		err = errors.Wrap(err, "xcrun not found on PATH.  macOS cgo cross-builds require Xcode's command line tools.")
+		return env, err
+	}
+
+	cmd := exec.Command(xcrun, "--sdk", sdk, "--show-sdk-path")
+
+	out, err := cmd.Output()
+	if err != nil {
+		err = errors.Wrapf(err, "failed to resolve SDK path for %q", sdk)
+		return env, err
+	}
+
+	sdkPath := strings.TrimSpace(string(out))
+
+	if verbose {
+		log.Printf("Resolved macOS SDK %q to %s", sdk, sdkPath)
+	}
+
+	arch := target.MacOSArch
+	if arch == "" {
+		arch = "x86_64"
+	}
+
+	env = []string{
+		fmt.Sprintf("SDKROOT=%s", sdkPath),
+		fmt.Sprintf("CGO_CFLAGS=-isysroot %s -arch %s", sdkPath, arch),
+		fmt.Sprintf("CGO_LDFLAGS=-isysroot %s -arch %s", sdkPath, arch),
+	}
+
+	return env, err
+}
+
 // BuildExtras builds the extra artifacts specified in the metadata.json
 func BuildExtras(meta Metadata, workdir string, verbose bool) (err error) {
 	if verbose {
@@ -0,0 +1,15 @@
+package gomason
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCArtifactExtension(t *testing.T) {
+	assert.Equal(t, "a", cArtifactExtension("c-archive", "linux"), "c-archive should always produce a .a regardless of OS")
+	assert.Equal(t, "a", cArtifactExtension("c-archive", "windows"), "c-archive should always produce a .a regardless of OS")
+	assert.Equal(t, "dll", cArtifactExtension("c-shared", "windows"), "c-shared on windows should produce a .dll")
+	assert.Equal(t, "dylib", cArtifactExtension("c-shared", "darwin"), "c-shared on darwin should produce a .dylib")
+	assert.Equal(t, "so", cArtifactExtension("c-shared", "linux"), "c-shared elsewhere should produce a .so")
+}
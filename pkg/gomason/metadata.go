@@ -0,0 +1,54 @@
+package gomason
+
+// Metadata models metadata.json, the per-project configuration file gomason reads its
+// build, sign, and test instructions from.
+type Metadata struct {
+	Package     string                 `json:"package"`
+	Version     string                 `json:"version"`
+	Description string                 `json:"description,omitempty"`
+	BuildInfo   BuildInfo              `json:"building,omitempty"`
+	Options     map[string]interface{} `json:"options,omitempty"`
+	// CrossToolchains maps a "<os>/<arch>" build target to the C/C++ cross-compilers
+	// buildTarget/buildCTarget should set CC/CXX to when that target's cgo is true.
+	CrossToolchains map[string]CrossToolchain `json:"cross_toolchains,omitempty"`
+}
+
+// BuildInfo holds the set of binaries and extra generated artifacts a build produces.
+type BuildInfo struct {
+	Targets []BuildTarget `json:"targets,omitempty"`
+	Extras  []Extra       `json:"extras,omitempty"`
+}
+
+// BuildTarget describes a single gox/go build invocation: the "<os>/<arch>" pair to
+// build for, plus the cgo, buildmode, tags and flag knobs buildTarget/buildCTarget need
+// to assemble that invocation's command line and environment.
+type BuildTarget struct {
+	Name  string            `json:"name"`
+	Cgo   bool              `json:"cgo,omitempty"`
+	Flags map[string]string `json:"flags,omitempty"`
+	// Buildmode selects a non-default 'go build -buildmode'.  "c-archive" and
+	// "c-shared" route the target through buildCTarget instead of gox.
+	Buildmode  string   `json:"buildmode,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	Ldflags    string   `json:"ldflags,omitempty"`
+	CgoCFlags  string   `json:"cgo_cflags,omitempty"`
+	CgoLDFlags string   `json:"cgo_ldflags,omitempty"`
+	// MacOSSDK and MacOSArch select the SDK ("macosx", "iphoneos", ...) and -arch value
+	// macOSCgoEnv resolves via xcrun for a darwin cgo build.  Both default when empty.
+	MacOSSDK  string `json:"macos_sdk,omitempty"`
+	MacOSArch string `json:"macos_arch,omitempty"`
+}
+
+// Extra describes a single templated artifact BuildExtras renders alongside the
+// compiled binaries, e.g. an install script or a checksum file.
+type Extra struct {
+	Template   string `json:"template"`
+	FileName   string `json:"filename"`
+	Executable bool   `json:"executable,omitempty"`
+}
+
+// CrossToolchain is the CC/CXX pair a cgo build target cross-compiles with.
+type CrossToolchain struct {
+	CC  string `json:"cc"`
+	CXX string `json:"cxx"`
+}
@@ -0,0 +1,102 @@
+package gomason
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"log"
+	"os"
+	"strings"
+)
+
+// PublishBuildTargets collects each of meta's BuildInfo.Targets artifacts out of the
+// ephemeral gopath workdir and into publishDir.  Plain executables are moved as a single
+// file; c-archive/c-shared targets are moved as a group alongside their generated header
+// (and, on Windows, their dlltool-produced import library) so the pieces a C consumer
+// needs stay together.  Signing isn't implemented yet for pkg/gomason - sign is accepted
+// so callers compile, but when true this simply reports that fact, same as
+// mason.WholeShebang does for the mason package's own not-yet-implemented signing step.
+func PublishBuildTargets(meta Metadata, gopath, publishDir string, sign, verbose bool) (err error) {
+	parts := strings.Split(meta.Package, "/")
+	binaryPrefix := parts[len(parts)-1]
+
+	workdir := fmt.Sprintf("%s/src/%s", gopath, meta.Package)
+
+	for _, target := range meta.BuildInfo.Targets {
+		archparts := strings.Split(target.Name, "/")
+		if len(archparts) != 2 {
+			err = fmt.Errorf("invalid build target %q, expected '<os>/<arch>'", target.Name)
+			return err
+		}
+
+		osname := archparts[0]
+		archname := archparts[1]
+
+		var group []string
+
+		if target.Buildmode == "c-archive" || target.Buildmode == "c-shared" {
+			libExt := cArtifactExtension(target.Buildmode, osname)
+
+			group = append(group,
+				fmt.Sprintf("%s_%s_%s.%s", binaryPrefix, osname, archname, libExt),
+				fmt.Sprintf("%s_%s_%s.h", binaryPrefix, osname, archname),
+			)
+
+			if osname == "windows" {
+				importLib := fmt.Sprintf("%s_%s_%s.lib", binaryPrefix, osname, archname)
+
+				if _, statErr := os.Stat(fmt.Sprintf("%s/%s", workdir, importLib)); statErr == nil {
+					group = append(group, importLib)
+				}
+			}
+		} else {
+			group = append(group, fmt.Sprintf("%s_%s_%s", binaryPrefix, osname, archname))
+		}
+
+		for _, name := range group {
+			src := fmt.Sprintf("%s/%s", workdir, name)
+			dest := fmt.Sprintf("%s/%s", publishDir, name)
+
+			if verbose {
+				log.Printf("Publishing %s to %s", src, dest)
+			}
+
+			if err = os.Rename(src, dest); err != nil {
+				err = errors.Wrapf(err, "failed to publish build artifact %s", src)
+				return err
+			}
+		}
+	}
+
+	if sign {
+		log.Printf("Signing not yet implemented for pkg/gomason.  Stay tuned\n")
+	}
+
+	return err
+}
+
+// PublishBuildExtras moves meta's BuildInfo.Extras artifacts from the ephemeral gopath
+// workdir into publishDir.  Signing isn't implemented yet for pkg/gomason; see
+// PublishBuildTargets.
+func PublishBuildExtras(meta Metadata, gopath, publishDir string, sign, verbose bool) (err error) {
+	workdir := fmt.Sprintf("%s/src/%s", gopath, meta.Package)
+
+	for _, extra := range meta.BuildInfo.Extras {
+		src := fmt.Sprintf("%s/%s", workdir, extra.FileName)
+		dest := fmt.Sprintf("%s/%s", publishDir, extra.FileName)
+
+		if verbose {
+			log.Printf("Publishing %s to %s", src, dest)
+		}
+
+		if err = os.Rename(src, dest); err != nil {
+			err = errors.Wrapf(err, "failed to publish extra artifact %s", src)
+			return err
+		}
+	}
+
+	if sign {
+		log.Printf("Signing not yet implemented for pkg/gomason.  Stay tuned\n")
+	}
+
+	return err
+}